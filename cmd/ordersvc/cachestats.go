@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/DmitryFreeLance/orders-demo/internal/cache"
+)
+
+// cacheStatsHandler exposes L1/L2 hit-miss ratios at GET /admin/cache/stats.
+func cacheStatsHandler(c *cache.Cache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats := c.Stats()
+
+		type tierView struct {
+			Hits, Misses int64
+			HitRatio     float64 `json:"hit_ratio"`
+		}
+		ratio := func(hits, misses int64) float64 {
+			if hits+misses == 0 { return 0 }
+			return float64(hits) / float64(hits+misses)
+		}
+		out := struct {
+			L1 tierView `json:"l1"`
+			L2 tierView `json:"l2"`
+		}{
+			L1: tierView{Hits: stats.L1Hits, Misses: stats.L1Misses, HitRatio: ratio(stats.L1Hits, stats.L1Misses)},
+			L2: tierView{Hits: stats.L2Hits, Misses: stats.L2Misses, HitRatio: ratio(stats.L2Hits, stats.L2Misses)},
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(out)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// dlqHandler exposes dead-lettered messages at GET /api/dlq.
+func dlqHandler(db *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rows, err := db.Query(r.Context(), `SELECT seq, reason, payload, received_at FROM orders_dlq ORDER BY seq DESC LIMIT 200`)
+		if err != nil { http.Error(w, "query failed", http.StatusInternalServerError); return }
+		defer rows.Close()
+
+		type dlqView struct {
+			Seq        int64           `json:"seq"`
+			Reason     string          `json:"reason"`
+			Payload    json.RawMessage `json:"payload"`
+			ReceivedAt time.Time       `json:"received_at"`
+		}
+		var out []dlqView
+		for rows.Next() {
+			var v dlqView
+			if err := rows.Scan(&v.Seq, &v.Reason, &v.Payload, &v.ReceivedAt); err != nil {
+				http.Error(w, "scan failed", http.StatusInternalServerError); return
+			}
+			out = append(out, v)
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(out)
+	}
+}
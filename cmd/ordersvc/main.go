@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"html/template"
@@ -10,15 +12,20 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
+	"github.com/DmitryFreeLance/orders-demo/internal/broker"
+	"github.com/DmitryFreeLance/orders-demo/internal/cache"
+	"github.com/DmitryFreeLance/orders-demo/internal/observability"
+	"github.com/DmitryFreeLance/orders-demo/internal/validate"
 	"github.com/jackc/pgx/v5/pgxpool"
-	stan "github.com/nats-io/stan.go"
 )
 
+var logger = observability.NewLogger()
+
 type Delivery struct {
 	Name, Phone, Zip, City, Address, Region, Email string
 }
@@ -40,17 +47,25 @@ type Item struct {
 	Status int64 `json:"status"`
 }
 type Order struct {
-	OrderUID, TrackNumber, Entry, Locale, InternalSign, CustomerID, DeliveryService, ShardKey, DateCreated, OOFShard string
-	Delivery Delivery
-	Payment  Payment
-	Items    []Item
-	SmID     int64 `json:"sm_id"`
+	OrderUID        string `json:"order_uid"`
+	TrackNumber     string `json:"track_number"`
+	Entry           string `json:"entry"`
+	Locale          string `json:"locale"`
+	InternalSign    string `json:"internal_signature"`
+	CustomerID      string `json:"customer_id"`
+	DeliveryService string `json:"delivery_service"`
+	ShardKey        string `json:"shardkey"`
+	DateCreated     string `json:"date_created"`
+	OOFShard        string `json:"oof_shard"`
+	Delivery        Delivery
+	Payment         Payment
+	Items           []Item
+	SmID            int64 `json:"sm_id"`
 }
 
 type Store struct {
 	db    *pgxpool.Pool
-	mu    sync.RWMutex
-	cache map[string]json.RawMessage
+	cache *cache.Cache
 }
 
 func mustEnv(key, def string) string {
@@ -58,14 +73,31 @@ func mustEnv(key, def string) string {
 	return def
 }
 
-func newStore(ctx context.Context, dsn string) (*Store, error) {
+// dlqReasonLabel buckets a validation error into a small, fixed set of
+// Prometheus label values. The full error text (unbounded cardinality) is
+// still logged and stored alongside the DLQ row — it just never reaches a
+// metric label.
+func dlqReasonLabel(err error) string {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "invalid JSON"):
+		return "invalid_json"
+	case strings.Contains(msg, "crossFieldAmount"):
+		return "amount_mismatch"
+	case strings.Contains(msg, "order_uid missing"):
+		return "missing_order_uid"
+	default:
+		return "schema_validation"
+	}
+}
+
+func newStore(ctx context.Context, dsn string, c *cache.Cache) (*Store, error) {
 	cfg, err := pgxpool.ParseConfig(dsn)
 	if err != nil { return nil, err }
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil { return nil, err }
-	s := &Store{db: pool, cache: make(map[string]json.RawMessage)}
+	s := &Store{db: pool, cache: c}
 	if err := s.initDB(ctx); err != nil { return nil, err }
-	if err := s.LoadCache(ctx); err != nil { return nil, err }
 	return s, nil
 }
 func (s *Store) Close(){ if s.db != nil { s.db.Close() } }
@@ -76,58 +108,119 @@ CREATE TABLE IF NOT EXISTS orders (
   payload    JSONB NOT NULL,
   created_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
-CREATE INDEX IF NOT EXISTS idx_orders_uid ON orders(order_uid);`
+CREATE INDEX IF NOT EXISTS idx_orders_uid ON orders(order_uid);
+-- btree expression indexes matching listOrdersHandler's actual ->> equality
+-- and numeric-range predicates; a jsonb_path_ops GIN index here would only
+-- serve @> containment queries, which none of those filters use.
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders((payload->>'customer_id'));
+CREATE INDEX IF NOT EXISTS idx_orders_delivery_service ON orders((payload->>'delivery_service'));
+CREATE INDEX IF NOT EXISTS idx_orders_payment_amount ON orders((((payload->'payment'->>'amount'))::numeric));
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tsv tsvector GENERATED ALWAYS AS (
+  to_tsvector('simple',
+    coalesce(payload->'delivery'->>'name', '') || ' ' ||
+    coalesce(payload->'delivery'->>'email', '') || ' ' ||
+    coalesce(payload->'delivery'->>'city', ''))
+) STORED;
+CREATE INDEX IF NOT EXISTS idx_orders_tsv_gin ON orders USING GIN (tsv);
+CREATE TABLE IF NOT EXISTS order_events (
+  id              BIGSERIAL PRIMARY KEY,
+  order_uid       TEXT NOT NULL,
+  event_type      TEXT NOT NULL,
+  payload         JSONB NOT NULL,
+  payload_hash    TEXT NOT NULL,
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+  delivered_at    TIMESTAMPTZ,
+  attempts        INT NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  delivered_urls  TEXT[] NOT NULL DEFAULT '{}'
+);
+CREATE INDEX IF NOT EXISTS idx_order_events_order_uid ON order_events(order_uid);
+CREATE INDEX IF NOT EXISTS idx_order_events_pending ON order_events(next_attempt_at) WHERE delivered_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_order_events_dedup ON order_events(order_uid, event_type, payload_hash);
+CREATE TABLE IF NOT EXISTS orders_dlq (
+  seq         BIGSERIAL PRIMARY KEY,
+  reason      TEXT NOT NULL,
+  payload     JSONB NOT NULL,
+  received_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);`
 	_, err := s.db.Exec(ctx, ddl)
 	return err
 }
-func (s *Store) LoadCache(ctx context.Context) error {
-	rows, err := s.db.Query(ctx, `SELECT order_uid, payload FROM orders`)
-	if err != nil { return err }
-	defer rows.Close()
-	n := 0
-	for rows.Next() {
-		var id string; var payload []byte
-		if err := rows.Scan(&id, &payload); err != nil { return err }
-		s.mu.Lock(); s.cache[id] = json.RawMessage(payload); s.mu.Unlock(); n++
-	}
-	log.Printf("cache restored: %d orders", n)
-	return rows.Err()
-}
 func (s *Store) Upsert(ctx context.Context, id string, payload []byte) error {
-	_, err := s.db.Exec(ctx, `INSERT INTO orders(order_uid, payload) VALUES($1,$2)
-	ON CONFLICT(order_uid) DO UPDATE SET payload=EXCLUDED.payload`, id, payload)
+	ctx, span := observability.StartSpan(ctx, "Store.Upsert")
+	defer span.End()
+	start := time.Now()
+	defer func() { observability.DBUpsertSeconds.Observe(time.Since(start).Seconds()) }()
+
+	tx, err := s.db.Begin(ctx)
 	if err != nil { return err }
-	s.mu.Lock(); s.cache[id] = json.RawMessage(payload); s.mu.Unlock()
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `INSERT INTO orders(order_uid, payload) VALUES($1,$2)
+	ON CONFLICT(order_uid) DO UPDATE SET payload=EXCLUDED.payload`, id, payload); err != nil {
+		return err
+	}
+	// payload_hash dedups the outbox row against broker redelivery of the
+	// same message (at-least-once ack semantics): a retried upsert with an
+	// unchanged payload hits the unique index and is a no-op here.
+	hash := sha256.Sum256(payload)
+	if _, err := tx.Exec(ctx, `INSERT INTO order_events(order_uid, event_type, payload, payload_hash) VALUES($1,$2,$3,$4)
+	ON CONFLICT (order_uid, event_type, payload_hash) DO NOTHING`,
+		id, "order.upserted", payload, hex.EncodeToString(hash[:])); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil { return err }
+
+	if err := s.cache.Set(ctx, id, json.RawMessage(payload)); err != nil {
+		logger.Warn("cache write-through failed", "order_uid", id, "error", err)
+	}
+	observability.CacheSize.Set(float64(s.cache.Len()))
 	return nil
 }
-func (s *Store) Get(id string) (json.RawMessage, bool) {
-	s.mu.RLock(); defer s.mu.RUnlock()
-	val, ok := s.cache[id]; return val, ok
-}
 
-// --- validation ---
-func minimalValidateOrder(payload []byte) (string, error) {
-	if !json.Valid(payload) { return "", errors.New("invalid JSON") }
-	var tmp struct {
-		OrderUID string `json:"order_uid"`
-		Delivery *json.RawMessage `json:"delivery"`
-		Payment  *json.RawMessage `json:"payment"`
-		Items    []json.RawMessage `json:"items"`
+// Get checks the L1/L2 cache, falling back to Postgres and backfilling
+// both tiers on a miss.
+func (s *Store) Get(ctx context.Context, id string) (json.RawMessage, bool) {
+	if payload, ok := s.cache.Get(ctx, id); ok { return payload, true }
+	if s.db == nil { return nil, false }
+
+	var payload []byte
+	err := s.db.QueryRow(ctx, `SELECT payload FROM orders WHERE order_uid=$1`, id).Scan(&payload)
+	if err != nil { return nil, false }
+
+	if err := s.cache.Set(ctx, id, json.RawMessage(payload)); err != nil {
+		logger.Warn("cache backfill failed", "order_uid", id, "error", err)
 	}
-	if err := json.Unmarshal(payload, &tmp); err != nil { return "", err }
-	if tmp.OrderUID == "" { return "", errors.New("missing order_uid") }
-	if tmp.Delivery == nil || tmp.Payment == nil || len(tmp.Items) == 0 {
-		return "", errors.New("missing required nested fields")
+	return json.RawMessage(payload), true
+}
+
+// InsertDLQ records a message that failed validation so it can be
+// inspected via GET /api/dlq instead of being silently dropped.
+func (s *Store) InsertDLQ(ctx context.Context, reason string, payload []byte) error {
+	// A payload that is not valid JSON is stored as a JSON string, so the
+	// JSONB column accepts it.
+	if !json.Valid(payload) {
+		payload, _ = json.Marshal(string(payload))
 	}
-	return tmp.OrderUID, nil
+	_, err := s.db.Exec(ctx, `INSERT INTO orders_dlq(reason, payload) VALUES($1,$2)`, reason, payload)
+	return err
 }
 
 // --- HTTP ---
+// apiHandler serves GET /api/orders/{id} and, since pre-1.22 ServeMux has
+// no wildcard routing, also dispatches /api/orders/{id}/events to
+// eventsByID based on a manually-parsed path suffix.
 func apiHandler(store *Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id := strings.TrimPrefix(r.URL.Path, "/api/orders/")
-		if id == "" || id == r.URL.Path { http.Error(w, "missing id", http.StatusBadRequest); return }
-		if payload, ok := store.Get(id); ok {
+		rest := strings.TrimPrefix(r.URL.Path, "/api/orders/")
+		if rest == "" || rest == r.URL.Path { http.Error(w, "missing id", http.StatusBadRequest); return }
+
+		if id, ok := strings.CutSuffix(rest, "/events"); ok {
+			eventsByID(store.db, id).ServeHTTP(w, r)
+			return
+		}
+
+		if payload, ok := store.Get(r.Context(), rest); ok {
 			w.Header().Set("Content-Type", "application/json; charset=utf-8")
 			w.WriteHeader(http.StatusOK); _, _ = w.Write(payload); return
 		}
@@ -145,6 +238,7 @@ body{font-family:system-ui,-apple-system,Segoe UI,Roboto,Arial,sans-serif;margin
 .row{display:flex;gap:24px;flex-wrap:wrap}.col{flex:1;min-width:260px}.muted{color:#666;font-size:.9em}
 pre{background:#f7f7f7;padding:12px;overflow:auto;border-radius:6px}
 input[type=text]{width:420px;padding:8px}button{padding:8px 12px;cursor:pointer}
+table{width:100%;border-collapse:collapse}th,td{text-align:left;padding:6px 8px;border-bottom:1px solid #eee}
 </style></head><body><div class="card">
 <h2>Поиск заказа</h2>
 <form method="GET" action="/orders">
@@ -177,20 +271,60 @@ input[type=text]{width:420px;padding:8px}button{padding:8px 12px;cursor:pointer}
   <details><summary>Показать сырой JSON</summary><pre>{{.Raw}}</pre></details>
 {{else if .ID}}
   <hr/><div>Заказ с id <code>{{.ID}}</code> не найден в кэше.</div>
+{{else if .Recent}}
+  <hr/><h3>Последние заказы</h3>
+  <table>
+    <thead><tr><th>order_uid</th><th>Создан</th><th>Клиент</th><th>Служба доставки</th></tr></thead>
+    <tbody>{{range .Recent}}
+      <tr>
+        <td><a href="/orders?id={{.OrderUID}}">{{.OrderUID}}</a></td>
+        <td class="muted">{{.CreatedAt.Format "2006-01-02 15:04"}}</td>
+        <td>{{.CustomerID}}</td>
+        <td>{{.DeliveryService}}</td>
+      </tr>
+    {{end}}</tbody>
+  </table>
 {{end}}
 </div></body></html>
 `))
 
+// recentOrderRow is the subset of fields the /orders listing table renders.
+type recentOrderRow struct {
+	OrderUID        string
+	CreatedAt       time.Time
+	CustomerID      string
+	DeliveryService string
+}
+
 func pageHandler(store *Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := r.URL.Query().Get("id")
-		var data struct{ ID string; Found bool; Order Order; Raw string }
+		var data struct {
+			ID     string
+			Found  bool
+			Order  Order
+			Raw    string
+			Recent []recentOrderRow
+		}
 		data.ID = id
 		if id != "" {
-			if payload, ok := store.Get(id); ok {
+			if payload, ok := store.Get(r.Context(), id); ok {
 				data.Found = true; data.Raw = string(payload)
 				_ = json.Unmarshal(payload, &data.Order)
 			}
+		} else if store.db != nil {
+			rows, err := recentOrders(store.db, r.Context(), 20)
+			if err != nil {
+				logger.Warn("recent orders query failed", "error", err)
+			}
+			for _, row := range rows {
+				var ord Order
+				_ = json.Unmarshal(row.Payload, &ord)
+				data.Recent = append(data.Recent, recentOrderRow{
+					OrderUID: row.OrderUID, CreatedAt: row.CreatedAt,
+					CustomerID: ord.CustomerID, DeliveryService: ord.DeliveryService,
+				})
+			}
 		}
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		_ = pageTpl.Execute(w, data)
@@ -205,50 +339,91 @@ func main() {
 	clientID := mustEnv("STAN_CLIENT_ID", "orders-service-1")
 	channel := mustEnv("STAN_CHANNEL", "orders")
 	durable := mustEnv("STAN_DURABLE", "orders-durable")
+	brokerKind := mustEnv("BROKER", "stan")
+	schemaPath := mustEnv("ORDER_SCHEMA_PATH", "schemas/order.schema.json")
+	otlpEndpoint := mustEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317")
+	cacheMaxEntries, err := strconv.Atoi(mustEnv("CACHE_MAX_ENTRIES", "10000"))
+	if err != nil { log.Fatalf("CACHE_MAX_ENTRIES: %v", err) }
+	cacheTTL, err := time.ParseDuration(mustEnv("CACHE_TTL", "10m"))
+	if err != nil { log.Fatalf("CACHE_TTL: %v", err) }
+	redisURL := mustEnv("REDIS_URL", "")
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM); defer cancel()
 
-	store, err := newStore(ctx, dsn)
+	shutdownTracing, err := observability.InitTracing(ctx, otlpEndpoint)
+	if err != nil { log.Fatalf("tracing init: %v", err) }
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second); defer cancel()
+		_ = shutdownTracing(shutdownCtx)
+	}()
+
+	orderCache, err := cache.New(ctx, cacheMaxEntries, redisURL, cacheTTL)
+	if err != nil { log.Fatalf("cache init: %v", err) }
+
+	store, err := newStore(ctx, dsn, orderCache)
 	if err != nil { log.Fatalf("db init: %v", err) }
 	defer store.Close()
 
-	sc, err := stan.Connect(clusterID, clientID, stan.NatsURL(natsURL), stan.SetConnectionLostHandler(
-		func(_ stan.Conn, reason error) { log.Printf("stan connection lost: %v", reason) }))
-	if err != nil { log.Fatalf("stan connect: %v", err) }
-	defer sc.Close()
+	validator, err := validate.New(schemaPath)
+	if err != nil { log.Fatalf("validator init: %v", err) }
 
-	_, err = sc.QueueSubscribe(channel, "workers", func(m *stan.Msg) {
-		id, vErr := minimalValidateOrder(m.Data)
-		if vErr != nil { log.Printf("drop invalid msg (seq=%d): %v", m.Sequence, vErr); _ = m.Ack(); return }
-		if err := store.Upsert(context.Background(), id, m.Data); err != nil {
-			log.Printf("db upsert failed (seq=%d, id=%s): %v", m.Sequence, id, err)
-			return
+	mb, err := broker.New(ctx, broker.Config{
+		Kind: brokerKind, URL: natsURL, ClusterID: clusterID, ClientID: clientID, Durable: durable,
+	})
+	if err != nil { log.Fatalf("broker connect: %v", err) }
+	defer mb.Close()
+
+	err = mb.Subscribe(ctx, channel, "workers", func(ctx context.Context, data []byte, seq uint64) error {
+		ctx, span := observability.StartSpan(ctx, "broker.handle")
+		defer span.End()
+		observability.StanInflight.Inc()
+		defer observability.StanInflight.Dec()
+		start := time.Now()
+		defer func() { observability.StanProcessingSeconds.Observe(time.Since(start).Seconds()) }()
+
+		id, vErr := validator.Order(data)
+		if vErr != nil {
+			reason := vErr.Error()
+			observability.WithOrder(logger, ctx, "", seq).Warn("drop invalid msg", "reason", reason)
+			observability.OrdersDLQTotal.WithLabelValues(dlqReasonLabel(vErr)).Inc()
+			if err := store.InsertDLQ(ctx, reason, data); err != nil {
+				logger.Error("dlq insert failed", "error", err)
+			}
+			observability.OrdersIngestedTotal.WithLabelValues("invalid").Inc()
+			return nil
 		}
-		_ = m.Ack()
-		log.Printf("stored order id=%s (seq=%d)", id, m.Sequence)
-	},
-		stan.DurableName(durable),
-		stan.DeliverAllAvailable(),
-		stan.SetManualAckMode(),
-		stan.AckWait(30*time.Second),
-		stan.MaxInflight(1),
-	)
-	if err != nil { log.Fatalf("stan subscribe: %v", err) }
+		if err := store.Upsert(ctx, id, data); err != nil {
+			observability.WithOrder(logger, ctx, id, seq).Error("db upsert failed", "error", err)
+			observability.OrdersIngestedTotal.WithLabelValues("error").Inc()
+			return err
+		}
+		observability.WithOrder(logger, ctx, id, seq).Info("stored order")
+		observability.OrdersIngestedTotal.WithLabelValues("ok").Inc()
+		return nil
+	})
+	if err != nil { log.Fatalf("broker subscribe: %v", err) }
+
+	dispatcher := newDispatcher(store.db, mustEnv("WEBHOOK_URLS", ""), mustEnv("WEBHOOK_SECRET", ""))
+	go dispatcher.Run(ctx)
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/orders/", apiHandler(store))
-	mux.HandleFunc("/orders", pageHandler(store))
+	mux.HandleFunc("/api/orders", observability.Instrument("/api/orders", listOrdersHandler(store.db)))
+	mux.HandleFunc("/api/orders/", observability.Instrument("/api/orders/", apiHandler(store)))
+	mux.HandleFunc("/orders", observability.Instrument("/orders", pageHandler(store)))
+	mux.HandleFunc("/api/dlq", observability.Instrument("/api/dlq", dlqHandler(store.db)))
+	mux.HandleFunc("/admin/cache/stats", observability.Instrument("/admin/cache/stats", cacheStatsHandler(store.cache)))
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request){ http.Redirect(w, r, "/orders", http.StatusFound) })
+	mux.Handle("/metrics", observability.Handler())
 	srv := &http.Server{ Addr: addr, Handler: mux, ReadHeaderTimeout: 5*time.Second }
 
 	go func(){
-		log.Printf("http listen on %s", addr)
+		logger.Info("http listen", "addr", addr)
 		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) && err != nil {
 			log.Fatalf("http: %v", err)
 		}
 	}()
 	<-ctx.Done()
-	log.Printf("shutting down...")
+	logger.Info("shutting down")
 	shutdownCtx, cancel2 := context.WithTimeout(context.Background(), 5*time.Second); defer cancel2()
 	_ = srv.Shutdown(shutdownCtx)
 	_ = sql.ErrNoRows
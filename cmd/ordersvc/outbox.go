@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// outboxEvent mirrors a row of order_events.
+type outboxEvent struct {
+	ID            int64
+	OrderUID      string
+	EventType     string
+	Payload       json.RawMessage
+	Attempts      int
+	DeliveredURLs []string
+}
+
+// dispatcher polls the order_events outbox and fans pending rows out to the
+// configured webhook endpoints, retrying failed deliveries with exponential
+// backoff.
+type dispatcher struct {
+	db     *pgxpool.Pool
+	urls   []string
+	secret string
+	client *http.Client
+}
+
+func newDispatcher(db *pgxpool.Pool, urls, secret string) *dispatcher {
+	var list []string
+	for _, u := range strings.Split(urls, ",") {
+		if u = strings.TrimSpace(u); u != "" { list = append(list, u) }
+	}
+	return &dispatcher{db: db, urls: list, secret: secret, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (d *dispatcher) Run(ctx context.Context) {
+	if len(d.urls) == 0 {
+		log.Printf("outbox dispatcher disabled: WEBHOOK_URLS not set")
+		return
+	}
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.poll(ctx)
+		}
+	}
+}
+
+// claimLease bounds how long a claimed row is kept out of other instances'
+// polls before it's eligible again, in case this instance dies mid-delivery.
+const claimLease = 30 * time.Second
+
+func (d *dispatcher) poll(ctx context.Context) {
+	events, err := d.claim(ctx)
+	if err != nil { log.Printf("outbox poll: %v", err); return }
+	for _, e := range events {
+		d.deliver(ctx, e)
+	}
+}
+
+// claim locks pending rows with FOR UPDATE SKIP LOCKED and leases them by
+// pushing next_attempt_at out, so that when multiple service instances run
+// a dispatcher behind a load balancer, each row is delivered by exactly one
+// instance at a time instead of every instance firing the same webhook.
+func (d *dispatcher) claim(ctx context.Context) ([]outboxEvent, error) {
+	tx, err := d.db.Begin(ctx)
+	if err != nil { return nil, err }
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `SELECT id, order_uid, event_type, payload, attempts, delivered_urls FROM order_events
+	WHERE delivered_at IS NULL AND next_attempt_at <= now() ORDER BY id LIMIT 50 FOR UPDATE SKIP LOCKED`)
+	if err != nil { return nil, err }
+	var events []outboxEvent
+	for rows.Next() {
+		var e outboxEvent
+		if err := rows.Scan(&e.ID, &e.OrderUID, &e.EventType, &e.Payload, &e.Attempts, &e.DeliveredURLs); err != nil {
+			log.Printf("outbox scan: %v", err); continue
+		}
+		events = append(events, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil { return nil, err }
+	if len(events) == 0 { return nil, tx.Commit(ctx) }
+
+	ids := make([]int64, len(events))
+	for i, e := range events { ids[i] = e.ID }
+	if _, err := tx.Exec(ctx, `UPDATE order_events SET next_attempt_at=$2 WHERE id = ANY($1)`,
+		ids, time.Now().Add(claimLease)); err != nil {
+		return nil, err
+	}
+	return events, tx.Commit(ctx)
+}
+
+// deliver sends e to every configured URL that hasn't already succeeded for
+// it, so a partial failure on retry doesn't re-deliver to endpoints that
+// already got the event.
+func (d *dispatcher) deliver(ctx context.Context, e outboxEvent) {
+	already := make(map[string]bool, len(e.DeliveredURLs))
+	for _, u := range e.DeliveredURLs { already[u] = true }
+
+	delivered := append([]string{}, e.DeliveredURLs...)
+	allOK := true
+	for _, url := range d.urls {
+		if already[url] { continue }
+		if d.send(ctx, url, e) {
+			delivered = append(delivered, url)
+		} else {
+			allOK = false
+		}
+	}
+
+	if allOK {
+		_, err := d.db.Exec(ctx, `UPDATE order_events SET delivered_at=now(), attempts=attempts+1, delivered_urls=$2 WHERE id=$1`,
+			e.ID, delivered)
+		if err != nil { log.Printf("outbox mark delivered (id=%d): %v", e.ID, err) }
+		return
+	}
+	backoff := time.Duration(1<<uint(minInt(e.Attempts, 6))) * time.Second
+	_, err := d.db.Exec(ctx, `UPDATE order_events SET attempts=attempts+1, next_attempt_at=now()+$2, delivered_urls=$3 WHERE id=$1`,
+		e.ID, backoff, delivered)
+	if err != nil { log.Printf("outbox reschedule (id=%d): %v", e.ID, err) }
+}
+
+func (d *dispatcher) send(ctx context.Context, url string, e outboxEvent) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(e.Payload))
+	if err != nil { log.Printf("outbox build request (id=%d): %v", e.ID, err); return false }
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", e.EventType)
+	if d.secret != "" {
+		req.Header.Set("X-Signature", signPayload(d.secret, e.Payload))
+	}
+	resp, err := d.client.Do(req)
+	if err != nil { log.Printf("outbox deliver (id=%d, url=%s): %v", e.ID, url, err); return false }
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Printf("outbox deliver (id=%d, url=%s): status %d", e.ID, url, resp.StatusCode)
+		return false
+	}
+	return true
+}
+
+func signPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func minInt(a, b int) int { if a < b { return a }; return b }
+
+// eventsByID exposes delivery status for one order's outbox rows, reached
+// via GET /api/orders/{id}/events through apiHandler's manual routing.
+func eventsByID(db *pgxpool.Pool, id string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rows, err := db.Query(r.Context(), `SELECT id, event_type, created_at, delivered_at, attempts, next_attempt_at
+		FROM order_events WHERE order_uid=$1 ORDER BY id`, id)
+		if err != nil { http.Error(w, "query failed", http.StatusInternalServerError); return }
+		defer rows.Close()
+
+		type eventView struct {
+			ID            int64      `json:"id"`
+			EventType     string     `json:"event_type"`
+			CreatedAt     time.Time  `json:"created_at"`
+			DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+			Attempts      int        `json:"attempts"`
+			NextAttemptAt time.Time  `json:"next_attempt_at"`
+		}
+		var out []eventView
+		for rows.Next() {
+			var ev eventView
+			if err := rows.Scan(&ev.ID, &ev.EventType, &ev.CreatedAt, &ev.DeliveredAt, &ev.Attempts, &ev.NextAttemptAt); err != nil {
+				http.Error(w, "scan failed", http.StatusInternalServerError); return
+			}
+			out = append(out, ev)
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(out)
+	}
+}
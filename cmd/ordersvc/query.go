@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const defaultListLimit = 20
+const maxListLimit = 100
+
+// listCursor encodes the last row of a page, for keyset pagination.
+type listCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	OrderUID  string    `json:"order_uid"`
+}
+
+func encodeCursor(c listCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (listCursor, error) {
+	var c listCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil { return c, err }
+	err = json.Unmarshal(b, &c)
+	return c, err
+}
+
+// listOrdersRow is one row of the /api/orders listing.
+type listOrdersRow struct {
+	OrderUID  string          `json:"order_uid"`
+	CreatedAt time.Time       `json:"created_at"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// recentOrders fetches the most recently created orders, used to render
+// the /orders listing table.
+func recentOrders(db *pgxpool.Pool, ctx context.Context, limit int) ([]listOrdersRow, error) {
+	rows, err := db.Query(ctx, `SELECT order_uid, created_at, payload FROM orders ORDER BY created_at DESC, order_uid DESC LIMIT $1`, limit)
+	if err != nil { return nil, err }
+	defer rows.Close()
+
+	var items []listOrdersRow
+	for rows.Next() {
+		var row listOrdersRow
+		if err := rows.Scan(&row.OrderUID, &row.CreatedAt, &row.Payload); err != nil { return nil, err }
+		items = append(items, row)
+	}
+	return items, rows.Err()
+}
+
+// listOrdersHandler serves GET /api/orders with filtering, full-text
+// search over delivery name/email/city, and cursor-based pagination.
+func listOrdersHandler(db *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		limit := defaultListLimit
+		if v := q.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 { http.Error(w, "invalid limit", http.StatusBadRequest); return }
+			limit = n
+		}
+		if limit > maxListLimit { limit = maxListLimit }
+
+		where := []string{"1=1"}
+		args := []any{}
+		arg := func(v any) string { args = append(args, v); return fmt.Sprintf("$%d", len(args)) }
+
+		if v := q.Get("customer_id"); v != "" {
+			where = append(where, "payload->>'customer_id' = "+arg(v))
+		}
+		if v := q.Get("delivery_service"); v != "" {
+			where = append(where, "payload->>'delivery_service' = "+arg(v))
+		}
+		if v := q.Get("date_from"); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil { http.Error(w, "invalid date_from", http.StatusBadRequest); return }
+			where = append(where, "created_at >= "+arg(t))
+		}
+		if v := q.Get("date_to"); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil { http.Error(w, "invalid date_to", http.StatusBadRequest); return }
+			where = append(where, "created_at <= "+arg(t))
+		}
+		if v := q.Get("min_amount"); v != "" {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil { http.Error(w, "invalid min_amount", http.StatusBadRequest); return }
+			where = append(where, "(payload->'payment'->>'amount')::numeric >= "+arg(n))
+		}
+		if v := q.Get("max_amount"); v != "" {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil { http.Error(w, "invalid max_amount", http.StatusBadRequest); return }
+			where = append(where, "(payload->'payment'->>'amount')::numeric <= "+arg(n))
+		}
+		if v := q.Get("q"); v != "" {
+			where = append(where, "tsv @@ plainto_tsquery('simple', "+arg(v)+")")
+		}
+		if v := q.Get("cursor"); v != "" {
+			c, err := decodeCursor(v)
+			if err != nil { http.Error(w, "invalid cursor", http.StatusBadRequest); return }
+			where = append(where, fmt.Sprintf("(created_at, order_uid) < (%s, %s)", arg(c.CreatedAt), arg(c.OrderUID)))
+		}
+
+		sql := fmt.Sprintf(`SELECT order_uid, created_at, payload FROM orders WHERE %s
+		ORDER BY created_at DESC, order_uid DESC LIMIT %d`, strings.Join(where, " AND "), limit+1)
+
+		rows, err := db.Query(r.Context(), sql, args...)
+		if err != nil { http.Error(w, "query failed", http.StatusInternalServerError); return }
+		defer rows.Close()
+
+		var items []listOrdersRow
+		for rows.Next() {
+			var row listOrdersRow
+			if err := rows.Scan(&row.OrderUID, &row.CreatedAt, &row.Payload); err != nil {
+				http.Error(w, "scan failed", http.StatusInternalServerError); return
+			}
+			items = append(items, row)
+		}
+
+		var nextCursor string
+		if len(items) > limit {
+			last := items[limit-1]
+			nextCursor = encodeCursor(listCursor{CreatedAt: last.CreatedAt, OrderUID: last.OrderUID})
+			items = items[:limit]
+		}
+
+		out := struct {
+			Items      []listOrdersRow `json:"items"`
+			NextCursor string          `json:"next_cursor,omitempty"`
+		}{Items: items, NextCursor: nextCursor}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(out)
+	}
+}
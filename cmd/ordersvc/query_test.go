@@ -0,0 +1,17 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := listCursor{CreatedAt: time.Date(2021, 7, 25, 12, 0, 0, 0, time.UTC), OrderUID: "b563"}
+	got, err := decodeCursor(encodeCursor(want))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || got.OrderUID != want.OrderUID {
+		t.Fatalf("roundtrip mismatch: got %+v, want %+v", got, want)
+	}
+}
@@ -1,31 +1,37 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"time"
-	stan "github.com/nats-io/stan.go"
+
+	"github.com/DmitryFreeLance/orders-demo/internal/broker"
 )
 
 func main() {
 	var (
-		file      = flag.String("file", "/data/model.json", "path to JSON file to publish")
-		clusterID = flag.String("cluster", "test-cluster", "stan cluster id")
-		clientID  = flag.String("client", fmt.Sprintf("publisher-%d", time.Now().UnixNano()), "stan client id")
-		channel   = flag.String("channel", "orders", "stan channel")
-		natsURL   = flag.String("nats", "nats://nats-streaming:4222", "nats url")
+		file       = flag.String("file", "/data/model.json", "path to JSON file to publish")
+		brokerKind = flag.String("broker", "stan", "broker driver: stan|jetstream|kafka")
+		clusterID  = flag.String("cluster", "test-cluster", "stan cluster id")
+		clientID   = flag.String("client", fmt.Sprintf("publisher-%d", time.Now().UnixNano()), "stan client id")
+		channel    = flag.String("channel", "orders", "channel/topic to publish to")
+		natsURL    = flag.String("nats", "nats://nats-streaming:4222", "nats/kafka broker url")
 	)
 	flag.Parse()
 
 	payload, err := os.ReadFile(*file)
 	if err != nil { log.Fatalf("read file: %v", err) }
 
-	sc, err := stan.Connect(*clusterID, *clientID, stan.NatsURL(*natsURL))
-	if err != nil { log.Fatalf("stan connect: %v", err) }
-	defer sc.Close()
+	ctx := context.Background()
+	mb, err := broker.New(ctx, broker.Config{
+		Kind: *brokerKind, URL: *natsURL, ClusterID: *clusterID, ClientID: *clientID,
+	})
+	if err != nil { log.Fatalf("broker connect: %v", err) }
+	defer mb.Close()
 
-	if err := sc.Publish(*channel, payload); err != nil { log.Fatalf("publish: %v", err) }
-	log.Printf("published %d bytes to channel %q", len(payload), *channel)
-}
\ No newline at end of file
+	if err := mb.Publish(ctx, *channel, payload); err != nil { log.Fatalf("publish: %v", err) }
+	log.Printf("published %d bytes to channel %q via %s", len(payload), *channel, *brokerKind)
+}
@@ -0,0 +1,47 @@
+// Package broker abstracts the message-broker driver used for ingesting
+// orders, so the STAN (NATS Streaming) backend can be swapped for
+// JetStream or Kafka without touching the service code.
+package broker
+
+import (
+	"context"
+	"fmt"
+)
+
+// Handler processes one message. seq is the driver-native sequence/offset
+// number (STAN sequence, JetStream stream sequence, Kafka offset), passed
+// through for logging/correlation. Returning a nil error acknowledges the
+// message; a non-nil error leaves it for redelivery.
+type Handler func(ctx context.Context, data []byte, seq uint64) error
+
+// Broker is the minimal pub/sub surface the order service depends on.
+type Broker interface {
+	Subscribe(ctx context.Context, channel, group string, handler Handler) error
+	Publish(ctx context.Context, channel string, payload []byte) error
+	Close() error
+}
+
+// Config carries the environment-driven settings for every driver. Fields
+// irrelevant to the selected Kind are ignored.
+type Config struct {
+	Kind      string // "stan", "jetstream" or "kafka"
+	URL       string // NATS or Kafka broker address
+	ClusterID string // STAN cluster id
+	ClientID  string // STAN client id
+	Durable   string // durable/consumer name
+	MaxDeliver int
+}
+
+// New builds the Broker selected by cfg.Kind.
+func New(ctx context.Context, cfg Config) (Broker, error) {
+	switch cfg.Kind {
+	case "", "stan":
+		return newStanBroker(cfg)
+	case "jetstream":
+		return newJetstreamBroker(ctx, cfg)
+	case "kafka":
+		return newKafkaBroker(cfg)
+	default:
+		return nil, fmt.Errorf("broker: unknown kind %q", cfg.Kind)
+	}
+}
@@ -0,0 +1,71 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// jetstreamBroker uses a durable JetStream pull consumer, the replacement
+// for STAN's queue-subscribe + manual ack model.
+type jetstreamBroker struct {
+	nc         *nats.Conn
+	js         jetstream.JetStream
+	cc         jetstream.ConsumeContext
+	durable    string
+	maxDeliver int
+}
+
+func newJetstreamBroker(ctx context.Context, cfg Config) (*jetstreamBroker, error) {
+	nc, err := nats.Connect(cfg.URL)
+	if err != nil { return nil, err }
+	js, err := jetstream.New(nc)
+	if err != nil { nc.Close(); return nil, err }
+	maxDeliver := cfg.MaxDeliver
+	if maxDeliver <= 0 { maxDeliver = 5 }
+	return &jetstreamBroker{nc: nc, js: js, durable: cfg.Durable, maxDeliver: maxDeliver}, nil
+}
+
+func (b *jetstreamBroker) Subscribe(ctx context.Context, channel, group string, handler Handler) error {
+	stream, err := b.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     channel,
+		Subjects: []string{channel},
+	})
+	if err != nil { return fmt.Errorf("jetstream: create stream: %w", err) }
+
+	cons, err := stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:       b.durable,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		MaxDeliver:    b.maxDeliver,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
+	})
+	if err != nil { return fmt.Errorf("jetstream: create consumer: %w", err) }
+
+	cc, err := cons.Consume(func(msg jetstream.Msg) {
+		var seq uint64
+		if meta, err := msg.Metadata(); err == nil { seq = meta.Sequence.Stream }
+		if err := handler(ctx, msg.Data(), seq); err != nil {
+			log.Printf("jetstream handler error (seq=%d): %v", seq, err)
+			_ = msg.Nak()
+			return
+		}
+		_ = msg.Ack()
+	})
+	if err != nil { return fmt.Errorf("jetstream: consume: %w", err) }
+	b.cc = cc
+	return nil
+}
+
+func (b *jetstreamBroker) Publish(ctx context.Context, channel string, payload []byte) error {
+	_, err := b.js.Publish(ctx, channel, payload)
+	return err
+}
+
+func (b *jetstreamBroker) Close() error {
+	if b.cc != nil { b.cc.Stop() }
+	b.nc.Close()
+	return nil
+}
@@ -0,0 +1,78 @@
+package broker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// fetchRetryBackoff throttles the fetch loop after a persistent error
+// (broker down, topic missing, auth failure) so it doesn't busy-spin, and
+// spaces out redeliveries of a message whose handler returned an error.
+const fetchRetryBackoff = time.Second
+
+// kafkaBroker uses segmentio/kafka-go, treating "channel" as the topic and
+// "group" as the consumer group id.
+type kafkaBroker struct {
+	brokers []string
+	reader  *kafka.Reader
+	writer  *kafka.Writer
+}
+
+func newKafkaBroker(cfg Config) (*kafkaBroker, error) {
+	return &kafkaBroker{brokers: []string{cfg.URL}}, nil
+}
+
+func (b *kafkaBroker) Subscribe(ctx context.Context, channel, group string, handler Handler) error {
+	b.reader = kafka.NewReader(kafka.ReaderConfig{
+		Brokers: b.brokers,
+		Topic:   channel,
+		GroupID: group,
+	})
+	go func() {
+		for {
+			m, err := b.reader.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil { return }
+				log.Printf("kafka fetch: %v", err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(fetchRetryBackoff):
+				}
+				continue
+			}
+			// Redeliver the same message until the handler accepts it, so a
+			// later commit never skips past a failed offset.
+			for {
+				err := handler(ctx, m.Value, uint64(m.Offset))
+				if err == nil { break }
+				log.Printf("kafka handler error (offset=%d): %v", m.Offset, err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(fetchRetryBackoff):
+				}
+			}
+			if err := b.reader.CommitMessages(ctx, m); err != nil {
+				log.Printf("kafka commit (offset=%d): %v", m.Offset, err)
+			}
+		}
+	}()
+	return nil
+}
+
+func (b *kafkaBroker) Publish(ctx context.Context, channel string, payload []byte) error {
+	if b.writer == nil {
+		b.writer = &kafka.Writer{Addr: kafka.TCP(b.brokers...), Topic: channel, Balancer: &kafka.LeastBytes{}}
+	}
+	return b.writer.WriteMessages(ctx, kafka.Message{Value: payload})
+}
+
+func (b *kafkaBroker) Close() error {
+	if b.reader != nil { _ = b.reader.Close() }
+	if b.writer != nil { _ = b.writer.Close() }
+	return nil
+}
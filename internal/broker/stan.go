@@ -0,0 +1,52 @@
+package broker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	stan "github.com/nats-io/stan.go"
+)
+
+// stanBroker wraps a NATS Streaming connection. This is the original
+// behavior, kept as the default driver until STAN is fully retired.
+type stanBroker struct {
+	sc      stan.Conn
+	sub     stan.Subscription
+	durable string
+}
+
+func newStanBroker(cfg Config) (*stanBroker, error) {
+	sc, err := stan.Connect(cfg.ClusterID, cfg.ClientID, stan.NatsURL(cfg.URL), stan.SetConnectionLostHandler(
+		func(_ stan.Conn, reason error) { log.Printf("stan connection lost: %v", reason) }))
+	if err != nil { return nil, err }
+	return &stanBroker{sc: sc, durable: cfg.Durable}, nil
+}
+
+func (b *stanBroker) Subscribe(ctx context.Context, channel, group string, handler Handler) error {
+	sub, err := b.sc.QueueSubscribe(channel, group, func(m *stan.Msg) {
+		if err := handler(ctx, m.Data, m.Sequence); err != nil {
+			log.Printf("stan handler error (seq=%d): %v", m.Sequence, err)
+			return
+		}
+		_ = m.Ack()
+	},
+		stan.DurableName(b.durable),
+		stan.DeliverAllAvailable(),
+		stan.SetManualAckMode(),
+		stan.AckWait(30*time.Second),
+		stan.MaxInflight(1),
+	)
+	if err != nil { return err }
+	b.sub = sub
+	return nil
+}
+
+func (b *stanBroker) Publish(ctx context.Context, channel string, payload []byte) error {
+	return b.sc.Publish(channel, payload)
+}
+
+func (b *stanBroker) Close() error {
+	if b.sub != nil { _ = b.sub.Close() }
+	return b.sc.Close()
+}
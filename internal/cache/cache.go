@@ -0,0 +1,125 @@
+// Package cache provides the two-tier order cache: a bounded in-process
+// LRU (L1) backed by an optional Redis tier (L2), with pub/sub
+// invalidation so peer instances behind a load balancer evict stale L1
+// entries after a write.
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const invalidationChannel = "orders:cache:invalidate"
+
+// invalidationSep separates the publishing instance's id from the order id
+// in an invalidation message, so that instance can recognize and ignore its
+// own writes (Redis delivers PUBLISH to the publisher's own SUBSCRIBE).
+const invalidationSep = "|"
+
+// Stats reports hit/miss counts per tier.
+type Stats struct {
+	L1Hits, L1Misses int64
+	L2Hits, L2Misses int64
+}
+
+// Cache is the two-tier order cache. L2 and its pub/sub subscription are
+// nil when REDIS_URL is not configured, making Redis purely optional.
+type Cache struct {
+	l1  *lru.Cache[string, json.RawMessage]
+	l2  *redis.Client
+	ttl time.Duration
+
+	instanceID string
+
+	l1Hits, l1Misses, l2Hits, l2Misses int64
+}
+
+// New builds a cache with an L1 of at most maxEntries, plus an L2 on
+// redisURL when non-empty. A background subscriber evicts L1 entries that
+// peers invalidate.
+func New(ctx context.Context, maxEntries int, redisURL string, ttl time.Duration) (*Cache, error) {
+	l1, err := lru.New[string, json.RawMessage](maxEntries)
+	if err != nil { return nil, err }
+	c := &Cache{l1: l1, ttl: ttl, instanceID: newInstanceID()}
+
+	if redisURL != "" {
+		opt, err := redis.ParseURL(redisURL)
+		if err != nil { return nil, err }
+		c.l2 = redis.NewClient(opt)
+		go c.subscribeInvalidations(ctx)
+	}
+	return c, nil
+}
+
+func newInstanceID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Get checks L1 then L2, promoting an L2 hit back into L1.
+func (c *Cache) Get(ctx context.Context, id string) (json.RawMessage, bool) {
+	if payload, ok := c.l1.Get(id); ok {
+		atomic.AddInt64(&c.l1Hits, 1)
+		return payload, true
+	}
+	atomic.AddInt64(&c.l1Misses, 1)
+
+	if c.l2 == nil { return nil, false }
+	raw, err := c.l2.Get(ctx, id).Bytes()
+	if err != nil {
+		atomic.AddInt64(&c.l2Misses, 1)
+		return nil, false
+	}
+	atomic.AddInt64(&c.l2Hits, 1)
+	payload := json.RawMessage(raw)
+	c.l1.Add(id, payload)
+	return payload, true
+}
+
+// Set writes through both tiers and tells peer instances to evict their L1
+// copy of id.
+func (c *Cache) Set(ctx context.Context, id string, payload json.RawMessage) error {
+	c.l1.Add(id, payload)
+	if c.l2 == nil { return nil }
+	if err := c.l2.Set(ctx, id, []byte(payload), c.ttl).Err(); err != nil { return err }
+	return c.l2.Publish(ctx, invalidationChannel, c.instanceID+invalidationSep+id).Err()
+}
+
+// Len reports the current L1 entry count, used for the cache_size gauge.
+func (c *Cache) Len() int { return c.l1.Len() }
+
+// Stats snapshots hit/miss counters per tier.
+func (c *Cache) Stats() Stats {
+	return Stats{
+		L1Hits:   atomic.LoadInt64(&c.l1Hits),
+		L1Misses: atomic.LoadInt64(&c.l1Misses),
+		L2Hits:   atomic.LoadInt64(&c.l2Hits),
+		L2Misses: atomic.LoadInt64(&c.l2Misses),
+	}
+}
+
+func (c *Cache) subscribeInvalidations(ctx context.Context) {
+	sub := c.l2.Subscribe(ctx, invalidationChannel)
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok { return }
+			origin, id, found := strings.Cut(msg.Payload, invalidationSep)
+			if !found || origin == c.instanceID { continue }
+			c.l1.Remove(id)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+	"os"
+)
+
+// NewLogger builds the service's JSON structured logger.
+func NewLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
+
+// WithOrder returns a logger carrying trace_id, order_uid and msg_seq (the
+// broker-native sequence/offset of the message being processed, if any) as
+// structured attributes, to be threaded through a request/message context.
+func WithOrder(logger *slog.Logger, ctx context.Context, orderUID string, msgSeq uint64) *slog.Logger {
+	l := logger.With("trace_id", TraceID(ctx), "order_uid", orderUID)
+	if msgSeq != 0 { l = l.With("msg_seq", msgSeq) }
+	return l
+}
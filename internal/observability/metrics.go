@@ -0,0 +1,58 @@
+// Package observability wires up Prometheus metrics, structured slog
+// logging, and OpenTelemetry tracing for the order service. The ingestion
+// handler and HTTP server in main log through its slog logger; the outbox
+// dispatcher and the broker drivers log through the standard log package.
+package observability
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	OrdersIngestedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "orders_ingested_total",
+		Help: "Orders received from the broker, by outcome.",
+	}, []string{"result"})
+
+	OrdersDLQTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "orders_dlq_total",
+		Help: "Orders rejected to the dead-letter queue, by reason.",
+	}, []string{"reason"})
+
+	HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served, by route and status code.",
+	}, []string{"route", "code"})
+
+	StanProcessingSeconds = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name: "stan_processing_seconds",
+		Help: "Time spent processing one broker message end to end.",
+	})
+
+	DBUpsertSeconds = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name: "db_upsert_seconds",
+		Help: "Time spent in Store.Upsert, including the outbox insert.",
+	})
+
+	HTTPRequestDurationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "http_request_duration_seconds",
+		Help: "HTTP handler latency, by route.",
+	}, []string{"route"})
+
+	CacheSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "cache_size",
+		Help: "Number of orders currently held in the in-process cache.",
+	})
+
+	StanInflight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "stan_inflight",
+		Help: "Broker messages currently being processed.",
+	})
+)
+
+// Handler serves the Prometheus exposition format for GET /metrics.
+func Handler() http.Handler { return promhttp.Handler() }
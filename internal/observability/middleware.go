@@ -0,0 +1,34 @@
+package observability
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// statusRecorder captures the status code written by the wrapped handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Instrument wraps handler with a span, an http_requests_total counter, and
+// an http_request_duration_seconds observation, all labeled by route.
+func Instrument(route string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := StartSpan(r.Context(), "http "+route)
+		defer span.End()
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(rec, r.WithContext(ctx))
+
+		HTTPRequestDurationSeconds.WithLabelValues(route).Observe(time.Since(start).Seconds())
+		HTTPRequestsTotal.WithLabelValues(route, fmt.Sprintf("%d", rec.status)).Inc()
+	}
+}
@@ -0,0 +1,44 @@
+package observability
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "orders-demo/ordersvc"
+
+// InitTracing points the global TracerProvider at an OTLP/gRPC endpoint
+// (OTEL_EXPORTER_OTLP_ENDPOINT). The returned shutdown func flushes and
+// closes the exporter on graceful shutdown.
+func InitTracing(ctx context.Context, endpoint string) (shutdown func(context.Context) error, err error) {
+	exp, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(endpoint), otlptracegrpc.WithInsecure())
+	if err != nil { return nil, err }
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName("orders-demo-ordersvc"),
+	))
+	if err != nil { return nil, err }
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// StartSpan starts a span named name under the service's tracer.
+func StartSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name)
+}
+
+// TraceID returns the trace id of the span context in ctx, or "" if ctx
+// carries none.
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() { return "" }
+	return sc.TraceID().String()
+}
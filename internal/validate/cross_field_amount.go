@@ -0,0 +1,57 @@
+package validate
+
+import (
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// crossFieldAmountMeta validates the schema object the keyword is declared
+// on (not the keyword's own value) — it only constrains "crossFieldAmount"
+// itself to a boolean when present.
+var crossFieldAmountMeta = jsonschema.MustCompileString("crossFieldAmount.json", `{
+	"properties": {
+		"crossFieldAmount": {"type": "boolean"}
+	}
+}`)
+
+// crossFieldAmountCompiler activates crossFieldAmountSchema for any schema
+// node where "crossFieldAmount": true is set.
+type crossFieldAmountCompiler struct{}
+
+func (crossFieldAmountCompiler) Compile(ctx jsonschema.CompilerContext, m map[string]any) (jsonschema.ExtSchema, error) {
+	enabled, ok := m["crossFieldAmount"].(bool)
+	if !ok || !enabled { return nil, nil }
+	return crossFieldAmountSchema{}, nil
+}
+
+// crossFieldAmountSchema enforces
+// payment.amount == goods_total + delivery_cost + custom_fee - sum(items.sale)
+type crossFieldAmountSchema struct{}
+
+func (crossFieldAmountSchema) Validate(ctx jsonschema.ValidationContext, v any) error {
+	doc, ok := v.(map[string]any)
+	if !ok { return nil }
+	payment, ok := doc["payment"].(map[string]any)
+	if !ok { return nil }
+	items, _ := doc["items"].([]any)
+
+	amount := numberOf(payment["amount"])
+	goodsTotal := numberOf(payment["goods_total"])
+	deliveryCost := numberOf(payment["delivery_cost"])
+	customFee := numberOf(payment["custom_fee"])
+
+	var saleSum float64
+	for _, it := range items {
+		if item, ok := it.(map[string]any); ok { saleSum += numberOf(item["sale"]) }
+	}
+
+	want := goodsTotal + deliveryCost + customFee - saleSum
+	if amount != want {
+		return ctx.Error("crossFieldAmount", "payment.amount (%v) does not equal goods_total+delivery_cost+custom_fee-sum(items.sale) (%v)", amount, want)
+	}
+	return nil
+}
+
+func numberOf(v any) float64 {
+	f, _ := v.(float64)
+	return f
+}
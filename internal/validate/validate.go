@@ -0,0 +1,39 @@
+// Package validate checks incoming order payloads against
+// schemas/order.schema.json, replacing the old presence-only
+// minimalValidateOrder check with full JSON Schema validation plus a
+// custom cross-field keyword for the payment total.
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Validator wraps a compiled order schema.
+type Validator struct {
+	schema *jsonschema.Schema
+}
+
+// New compiles the schema at path, registering the crossFieldAmount
+// keyword used by schemas/order.schema.json.
+func New(path string) (*Validator, error) {
+	c := jsonschema.NewCompiler()
+	c.RegisterExtension("crossFieldAmount", crossFieldAmountMeta, crossFieldAmountCompiler{})
+	schema, err := c.Compile(path)
+	if err != nil { return nil, fmt.Errorf("validate: compile schema: %w", err) }
+	return &Validator{schema: schema}, nil
+}
+
+// Order validates payload against the order schema and returns its
+// order_uid on success.
+func (v *Validator) Order(payload []byte) (string, error) {
+	var doc any
+	if err := json.Unmarshal(payload, &doc); err != nil { return "", fmt.Errorf("invalid JSON: %w", err) }
+	if err := v.schema.Validate(doc); err != nil { return "", err }
+	m, _ := doc.(map[string]any)
+	id, _ := m["order_uid"].(string)
+	if id == "" { return "", fmt.Errorf("order_uid missing after validation") }
+	return id, nil
+}
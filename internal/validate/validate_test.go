@@ -0,0 +1,59 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+const schemaPath = "../../schemas/order.schema.json"
+
+func TestValidator_Order_OK(t *testing.T) {
+	v, err := New(schemaPath)
+	if err != nil { t.Fatalf("compile schema: %v", err) }
+
+	payload := []byte(`{
+	  "order_uid": "b563feb7b2b84b6test",
+	  "delivery": {"name":"Ivan","phone":"+70000000000","zip":"123","city":"Moscow","address":"Lenina 1","region":"MSK","email":"i@ex.com"},
+	  "payment": {"transaction":"t1","request_id":"r1","currency":"RUB","provider":"bank","amount":100,"payment_dt":1711111111,"bank":"bank","delivery_cost":10,"goods_total":90,"custom_fee":0},
+	  "items": [{"chrt_id":1,"track_number":"TN1","price":90,"rid":"rid","name":"Item","sale":0,"size":"L","total_price":90,"nm_id":1,"brand":"B","status":1}],
+	  "locale":"ru","internal_signature":"","customer_id":"c1","delivery_service":"svc","shardkey":"sh","sm_id":1,"date_created":"2021-07-25T12:00:00Z","oof_shard":"o1"
+	}`)
+	id, err := v.Order(payload)
+	if err != nil { t.Fatalf("unexpected error: %v", err) }
+	if id != "b563feb7b2b84b6test" { t.Fatalf("wrong id: %s", id) }
+}
+
+func TestValidator_Order_InvalidJSON(t *testing.T) {
+	v, err := New(schemaPath)
+	if err != nil { t.Fatalf("compile schema: %v", err) }
+
+	if _, err := v.Order([]byte(`not json`)); err == nil || !strings.Contains(err.Error(), "invalid JSON") {
+		t.Fatalf("expected invalid JSON error, got %v", err)
+	}
+}
+
+func TestValidator_Order_MissingID(t *testing.T) {
+	v, err := New(schemaPath)
+	if err != nil { t.Fatalf("compile schema: %v", err) }
+
+	payload := []byte(`{"delivery": {}, "payment": {}, "items": [{}], "date_created": "2021-07-25T12:00:00Z"}`)
+	if _, err := v.Order(payload); err == nil {
+		t.Fatal("expected validation error for missing order_uid")
+	}
+}
+
+func TestValidator_Order_AmountMismatch(t *testing.T) {
+	v, err := New(schemaPath)
+	if err != nil { t.Fatalf("compile schema: %v", err) }
+
+	payload := []byte(`{
+	  "order_uid": "b563feb7b2b84b6test",
+	  "delivery": {"name":"Ivan","phone":"+70000000000","zip":"123","city":"Moscow","address":"Lenina 1","region":"MSK","email":"i@ex.com"},
+	  "payment": {"transaction":"t1","currency":"RUB","amount":999,"delivery_cost":10,"goods_total":90,"custom_fee":0},
+	  "items": [{"chrt_id":1,"price":90,"rid":"rid","name":"Item","sale":0,"total_price":90}],
+	  "date_created": "2021-07-25T12:00:00Z"
+	}`)
+	if _, err := v.Order(payload); err == nil || !strings.Contains(err.Error(), "crossFieldAmount") {
+		t.Fatalf("expected crossFieldAmount error, got %v", err)
+	}
+}